@@ -0,0 +1,131 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/cli-experimental/internal/pkg/apply"
+	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
+	"sigs.k8s.io/cli-experimental/internal/pkg/reconcile"
+	"sigs.k8s.io/cli-experimental/internal/pkg/wirecli/wirek8s"
+)
+
+var (
+	inventoryNamespace string
+	inventoryName      string
+	workers            int
+)
+
+// GetApplyControllerCommand returns a new *cobra.Command for
+// `cli-experimental apply controller`
+func GetApplyControllerCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "controller",
+		Short: "Keeps applied resources converged with their inventory ConfigMap",
+		RunE:  runController,
+	}
+	wirek8s.Flags(cmd)
+	cmd.Flags().StringVar(&inventoryNamespace, "inventory-namespace", "default",
+		"namespace of the inventory ConfigMap to watch")
+	cmd.Flags().StringVar(&inventoryName, "inventory-name", "inventory",
+		"name of the inventory ConfigMap to watch")
+	cmd.Flags().IntVar(&workers, "workers", 1, "number of workers processing the reconcile queue")
+	return cmd
+}
+
+func runController(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("controller requires exactly 1 argument: the resource config path")
+	}
+	path := clik8s.ResourceConfigPath(args[0])
+
+	resources, err := readResources(path)
+	if err != nil {
+		return err
+	}
+
+	restConfig, err := wirek8s.NewRestConfig(wirek8s.NewMasterFlag(), wirek8s.NewKubeConfigPathFlag())
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := wirek8s.NewDynamicClient(restConfig)
+	if err != nil {
+		return err
+	}
+	discoveryClient, err := wirek8s.NewDiscoveryClient(restConfig)
+	if err != nil {
+		return err
+	}
+	clientset, err := wirek8s.NewKubernetesClientSet(restConfig)
+	if err != nil {
+		return err
+	}
+
+	planner := apply.NewPlanner(discoveryClient)
+	applier := &reconcile.DynamicApplier{DynamicClient: dynamicClient, Planner: planner}
+	pruner := &reconcile.InventoryPruner{
+		DynamicClient: dynamicClient,
+		Namespace:     inventoryNamespace,
+		Name:          inventoryName,
+		Planner:       planner,
+	}
+
+	// Apply once up front so the inventory ConfigMap exists before the
+	// controller starts watching it.
+	if err := applier.Apply(resources); err != nil {
+		return err
+	}
+
+	ctrl := reconcile.NewController(dynamicClient, inventoryNamespace, inventoryName, resources, applier, pruner)
+
+	hostname, _ := os.Hostname()
+	return reconcile.RunWithLeaderElection(ctrl, reconcile.RunOptions{
+		Clientset:     clientset,
+		LockNamespace: inventoryNamespace,
+		LockName:      inventoryName + "-controller-lock",
+		Identity:      hostname,
+		Workers:       workers,
+	})
+}
+
+// readResources loads the resource config at path through the same
+// providers wirek8s.NewResourceConfig wires together, and converts the
+// result to the concrete *unstructured.Unstructured type every provider
+// produces.
+func readResources(path clik8s.ResourceConfigPath) ([]*unstructured.Unstructured, error) {
+	kf := wirek8s.NewKustomizeFactory()
+	cp := wirek8s.NewConfigProvider(wirek8s.NewResMapFactory(kf), wirek8s.NewFileSystem(), wirek8s.NewTransformerFactory(kf))
+	hp := wirek8s.NewRawConfigHTTPProvider()
+	fp := wirek8s.NewRawConfigFileProvider()
+
+	configs, err := wirek8s.NewResourceConfig(path, cp, hp, fp)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*unstructured.Unstructured, 0, len(configs))
+	for _, c := range configs {
+		u, ok := c.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("resource config produced %T, expected *unstructured.Unstructured", c)
+		}
+		resources = append(resources, u)
+	}
+	return resources, nil
+}
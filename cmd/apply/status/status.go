@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/cli-experimental/internal/pkg/apply"
+	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
+	"sigs.k8s.io/cli-experimental/internal/pkg/wirecli"
+	"sigs.k8s.io/cli-experimental/internal/pkg/wirecli/wirek8s"
+)
+
+var watch bool
+
+// GetApplyStatusCommand returns a new *cobra.Command for `cli-experimental apply status`
+func GetApplyStatusCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Reports the status of applied resources",
+		RunE:  runStatus,
+	}
+	wirek8s.Flags(cmd)
+	cmd.Flags().BoolVar(&watch, "watch", false,
+		"keep running, streaming resource readiness transitions until all resources are Ready")
+	return cmd
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("status requires exactly 1 argument: the resource config path")
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Doing `cli-experimental apply status`\n")
+	path := clik8s.ResourceConfigPath(args[0])
+
+	s, err := wirecli.InitializeStatus(path, out)
+	if err != nil {
+		return err
+	}
+	s.Watch = watch
+
+	if watch {
+		restConfig, err := wirek8s.NewRestConfig(wirek8s.NewMasterFlag(), wirek8s.NewKubeConfigPathFlag())
+		if err != nil {
+			return err
+		}
+		discoveryClient, err := wirek8s.NewDiscoveryClient(restConfig)
+		if err != nil {
+			return err
+		}
+		s.Planner = apply.NewPlanner(discoveryClient)
+	}
+
+	result, err := s.Do(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Resources: %d\n", result.Resources)
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apply orders a resource set and classifies its members using live
+// cluster discovery, so Apply no longer depends on input ordering luck and
+// Prune no longer assumes every inventory entry lives in "default".
+package apply
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/restmapper"
+)
+
+// kindsAppliedFirst are applied ahead of everything else in a resource set,
+// since later resources may depend on them existing: Namespaces must exist
+// before namespaced objects, and CRDs must be installed before their CRs.
+var kindsAppliedFirst = map[string]bool{
+	"Namespace":                true,
+	"CustomResourceDefinition": true,
+}
+
+// Planner orders resource sets for Apply and tells Prune whether an
+// inventory entry is namespace- or cluster-scoped, using the live cluster's
+// discovery information instead of assumptions baked into the caller.
+type Planner struct {
+	// Discovery is used to build the GVK->REST mapping and to poll for
+	// newly installed CRD types
+	Discovery discovery.CachedDiscoveryInterface
+}
+
+// NewPlanner returns a Planner backed by dc.
+func NewPlanner(dc discovery.CachedDiscoveryInterface) *Planner {
+	return &Planner{Discovery: dc}
+}
+
+// Order returns resources with any Namespaces and CustomResourceDefinitions
+// moved to the front, preserving the relative order of everything else, so
+// a CRD and a CR of its type can be applied in the same run regardless of
+// the order the caller supplied them in.
+func (p *Planner) Order(resources []*unstructured.Unstructured) []*unstructured.Unstructured {
+	var first, rest []*unstructured.Unstructured
+	for _, r := range resources {
+		if kindsAppliedFirst[r.GetKind()] {
+			first = append(first, r)
+		} else {
+			rest = append(rest, r)
+		}
+	}
+	return append(first, rest...)
+}
+
+// restMapper builds a RESTMapper from the current discovery information.
+// It is rebuilt on every call rather than cached on the Planner, since the
+// underlying discovery.CachedDiscoveryInterface already caches the
+// ServerGroupsAndResources calls it's built from until Invalidate is called.
+func (p *Planner) restMapper() (meta.RESTMapper, error) {
+	groupResources, err := restmapper.GetAPIGroupResources(p.Discovery)
+	if err != nil {
+		return nil, fmt.Errorf("discovering API group resources: %v", err)
+	}
+	return restmapper.NewDiscoveryRESTMapper(groupResources), nil
+}
+
+// RESTMapping resolves gvk to its REST mapping - the GVR to address it with
+// and whether it's namespace- or cluster-scoped - using live discovery
+// instead of guessing the resource name from the Kind.
+func (p *Planner) RESTMapping(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+	mapper, err := p.restMapper()
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("mapping %s: %v", gvk, err)
+	}
+	return mapping, nil
+}
+
+// IsNamespaced reports whether gvk is namespace-scoped according to live
+// discovery.
+func (p *Planner) IsNamespaced(gvk schema.GroupVersionKind) (bool, error) {
+	mapping, err := p.RESTMapping(gvk)
+	if err != nil {
+		return false, err
+	}
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// WaitForResource polls discovery, invalidating the cache on each attempt,
+// until gvr is served by the API server or timeout elapses. Applying a CR in
+// the same run as the CRD that defines it races the API server publishing
+// the new type, so callers poll with this between applying CRDs and
+// applying their CRs.
+func (p *Planner) WaitForResource(gvr schema.GroupVersionResource, timeout time.Duration) error {
+	return wait.PollImmediate(100*time.Millisecond, timeout, func() (bool, error) {
+		p.Discovery.Invalidate()
+		resources, err := p.Discovery.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+		if err != nil {
+			return false, nil
+		}
+		for _, r := range resources.APIResources {
+			if r.Name == gvr.Resource {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apply
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"sigs.k8s.io/cli-experimental/internal/pkg/discoveryfake"
+)
+
+func newUnstructured(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func TestOrderMovesNamespacesAndCRDsFirst(t *testing.T) {
+	cm := newUnstructured("v1", "ConfigMap", "default", "cm1")
+	ns := newUnstructured("v1", "Namespace", "", "team-a")
+	crd := newUnstructured("apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "", "crontabs.stable.example.com")
+
+	p := NewPlanner(discoveryfake.New())
+	ordered := p.Order([]*unstructured.Unstructured{cm, ns, crd})
+
+	assert.Equal(t, []*unstructured.Unstructured{ns, crd, cm}, ordered)
+}
+
+func TestRESTMappingAndIsNamespaced(t *testing.T) {
+	dc := discoveryfake.New()
+	dc.Add(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, true)
+	dc.Add(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, false)
+
+	p := NewPlanner(dc)
+
+	mapping, err := p.RESTMapping(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+	assert.NoError(t, err)
+	assert.Equal(t, "configmaps", mapping.Resource.Resource)
+	assert.Equal(t, meta.RESTScopeNameNamespace, mapping.Scope.Name())
+
+	namespaced, err := p.IsNamespaced(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+	assert.NoError(t, err)
+	assert.True(t, namespaced)
+
+	clusterScoped, err := p.IsNamespaced(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"})
+	assert.NoError(t, err)
+	assert.False(t, clusterScoped)
+}
+
+func TestWaitForResourceReturnsOnceAdded(t *testing.T) {
+	dc := discoveryfake.New()
+	gvr := schema.GroupVersionResource{Group: "stable.example.com", Version: "v1", Resource: "crontabs"}
+	dc.Add(gvr, true)
+
+	p := NewPlanner(dc)
+	assert.NoError(t, p.WaitForResource(gvr, time.Second))
+}
+
+func TestWaitForResourceTimesOut(t *testing.T) {
+	p := NewPlanner(discoveryfake.New())
+	gvr := schema.GroupVersionResource{Group: "stable.example.com", Version: "v1", Resource: "crontabs"}
+
+	assert.Error(t, p.WaitForResource(gvr, 200*time.Millisecond))
+}
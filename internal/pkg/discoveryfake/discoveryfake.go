@@ -0,0 +1,74 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package discoveryfake provides a discovery.CachedDiscoveryInterface that
+// tests control directly, so a test can make a CRD's type "appear" the
+// moment it wants to instead of relying on a real API server's timing.
+package discoveryfake
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clientgotesting "k8s.io/client-go/testing"
+)
+
+// Client is a discovery.CachedDiscoveryInterface backed by an in-memory list
+// of API resources that a test populates with Add.
+type Client struct {
+	*fakediscovery.FakeDiscovery
+}
+
+// New returns a Client with no API resources registered.
+func New() *Client {
+	return &Client{
+		FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &clientgotesting.Fake{}},
+	}
+}
+
+// Add registers gvr as served by the fake server, so
+// ServerResourcesForGroupVersion and the RESTMapper built from
+// restmapper.GetAPIGroupResources both report it as installed.
+func (c *Client) Add(gvr schema.GroupVersionResource, namespaced bool) {
+	list := c.listFor(gvr.GroupVersion())
+	list.APIResources = append(list.APIResources, metav1.APIResource{
+		Name:       gvr.Resource,
+		Group:      gvr.Group,
+		Version:    gvr.Version,
+		Namespaced: namespaced,
+	})
+}
+
+// listFor returns the APIResourceList for gv, creating it if this is the
+// first resource registered for that group/version.
+func (c *Client) listFor(gv schema.GroupVersion) *metav1.APIResourceList {
+	for _, l := range c.Resources {
+		if l.GroupVersion == gv.String() {
+			return l
+		}
+	}
+	l := &metav1.APIResourceList{GroupVersion: gv.String()}
+	c.Resources = append(c.Resources, l)
+	return l
+}
+
+// Fresh implements discovery.CachedDiscoveryInterface. The fake has no
+// on-disk cache to go stale, so it always reports fresh.
+func (c *Client) Fresh() bool { return true }
+
+// Invalidate implements discovery.CachedDiscoveryInterface. Add mutates the
+// resource list directly, so there is nothing to invalidate.
+func (c *Client) Invalidate() {}
+
+var _ discovery.CachedDiscoveryInterface = &Client{}
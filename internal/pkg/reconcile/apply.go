@@ -0,0 +1,130 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"sigs.k8s.io/cli-experimental/internal/pkg/apply"
+)
+
+// crdWaitTimeout bounds how long Apply waits for a newly applied
+// CustomResourceDefinition's type to actually be served before giving up.
+const crdWaitTimeout = 30 * time.Second
+
+// DynamicApplier creates or updates resources with the dynamic client,
+// ordering them first so Namespaces and CustomResourceDefinitions land
+// before anything that depends on them.
+type DynamicApplier struct {
+	// DynamicClient is used to create or update each resource
+	DynamicClient dynamic.Interface
+
+	// Planner resolves each resource's GVR and scope via live discovery
+	Planner *apply.Planner
+}
+
+// Apply creates or updates every resource, in Planner.Order, waiting for
+// each applied CustomResourceDefinition's type to actually be served before
+// moving on - applying a CR in the same run as the CRD that defines it
+// otherwise races the API server publishing the new type.
+func (a *DynamicApplier) Apply(resources []*unstructured.Unstructured) error {
+	for _, r := range a.Planner.Order(resources) {
+		if err := a.applyOne(r); err != nil {
+			return fmt.Errorf("applying %s %s/%s: %v", r.GroupVersionKind(), r.GetNamespace(), r.GetName(), err)
+		}
+
+		if r.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+		for _, gvr := range crdServedGVRs(r) {
+			if err := a.Planner.WaitForResource(gvr, crdWaitTimeout); err != nil {
+				return fmt.Errorf("waiting for %s to be served: %v", gvr, err)
+			}
+		}
+	}
+	return nil
+}
+
+// crdServedGVRs extracts the served GroupVersionResources a
+// CustomResourceDefinition advertises, handling both the modern
+// spec.versions[].served list and the deprecated singular spec.version field.
+func crdServedGVRs(crd *unstructured.Unstructured) []schema.GroupVersionResource {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	if group == "" || plural == "" {
+		return nil
+	}
+
+	var versions []string
+	if vs, found, _ := unstructured.NestedSlice(crd.Object, "spec", "versions"); found {
+		for _, v := range vs {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if served, _ := m["served"].(bool); served {
+				if name, _ := m["name"].(string); name != "" {
+					versions = append(versions, name)
+				}
+			}
+		}
+	}
+	if len(versions) == 0 {
+		if v, found, _ := unstructured.NestedString(crd.Object, "spec", "version"); found && v != "" {
+			versions = append(versions, v)
+		}
+	}
+
+	gvrs := make([]schema.GroupVersionResource, 0, len(versions))
+	for _, v := range versions {
+		gvrs = append(gvrs, schema.GroupVersionResource{Group: group, Version: v, Resource: plural})
+	}
+	return gvrs
+}
+
+func (a *DynamicApplier) applyOne(r *unstructured.Unstructured) error {
+	ri, err := a.resourceInterface(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = ri.Create(r, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = ri.Update(r, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// resourceInterface resolves the namespaced or cluster-scoped
+// dynamic.ResourceInterface for r using live discovery.
+func (a *DynamicApplier) resourceInterface(r *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	mapping, err := a.Planner.RESTMapping(r.GroupVersionKind())
+	if err != nil {
+		return nil, err
+	}
+
+	ri := a.DynamicClient.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return ri.Namespace(r.GetNamespace()), nil
+	}
+	return ri, nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reconcile keeps a cluster converged with the resource set encoded
+// in an inventory ConfigMap, re-applying drifted objects and pruning removed
+// ones as the ConfigMap changes.
+package reconcile
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// defaultResync backstops the inventory informer against missed watch events.
+const defaultResync = 10 * time.Minute
+
+// configMapGVR is the GVR the inventory object is always stored as.
+var configMapGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// Applier applies resources to the cluster.
+type Applier interface {
+	Apply(resources []*unstructured.Unstructured) error
+}
+
+// Pruner deletes resources that have dropped out of the current set.
+type Pruner interface {
+	Prune(resources []*unstructured.Unstructured) error
+}
+
+// Controller watches an inventory ConfigMap and keeps the cluster converged
+// with Resources, the desired resource set the ConfigMap was generated from.
+type Controller struct {
+	// Informer watches the inventory ConfigMap
+	Informer cache.SharedIndexInformer
+
+	// Workqueue rate-limits processing of inventory ConfigMap keys
+	Workqueue workqueue.RateLimitingInterface
+
+	// Resources is the desired resource set: SyncHandler re-applies and
+	// prunes against these on every inventory ConfigMap change, rather than
+	// against objects read back from the live cluster, so drift away from
+	// this source of truth - including objects deleted out-of-band - is
+	// actually detected and healed.
+	Resources []*unstructured.Unstructured
+
+	// Applier re-applies Resources
+	Applier Applier
+
+	// Pruner deletes objects that are no longer part of Resources
+	Pruner Pruner
+}
+
+// NewController returns a Controller that watches the inventory ConfigMap
+// namespace/name and keeps the cluster converged with resources.
+func NewController(dc dynamic.Interface, namespace, name string, resources []*unstructured.Unstructured, applier Applier, pruner Pruner) *Controller {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dc, defaultResync, namespace,
+		func(opts *metav1.ListOptions) {
+			opts.FieldSelector = "metadata.name=" + name
+		})
+	informer := factory.ForResource(configMapGVR).Informer()
+
+	c := &Controller{
+		Informer:  informer,
+		Workqueue: workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		Resources: resources,
+		Applier:   applier,
+		Pruner:    pruner,
+	}
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueue,
+		UpdateFunc: func(_, new interface{}) { c.enqueue(new) },
+	})
+
+	return c
+}
+
+// enqueue adds the inventory ConfigMap's key to the workqueue.
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	c.Workqueue.Add(key)
+}
+
+// Run starts the informer and runs workers until stopCh is closed. It blocks
+// until stopCh is closed, so callers run it in a goroutine and close stopCh
+// on SIGTERM (see RunWithLeaderElection) for a graceful shutdown.
+func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
+	defer c.Workqueue.ShutDown()
+
+	go c.Informer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.Informer.HasSynced) {
+		return fmt.Errorf("timed out waiting for the inventory informer cache to sync")
+	}
+
+	for i := 0; i < workers; i++ {
+		go c.runWorker()
+	}
+
+	<-stopCh
+	return nil
+}
+
+func (c *Controller) runWorker() {
+	for c.processNextItem() {
+	}
+}
+
+func (c *Controller) processNextItem() bool {
+	key, shutdown := c.Workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.Workqueue.Done(key)
+
+	if err := c.SyncHandler(key.(string)); err != nil {
+		c.Workqueue.AddRateLimited(key)
+		return true
+	}
+	c.Workqueue.Forget(key)
+	return true
+}
+
+// SyncHandler re-applies Resources and prunes anything the inventory
+// ConfigMap tracks that has since dropped out of Resources. key is the
+// inventory ConfigMap that changed; it is only used to validate the
+// workqueue entry, since Resources (not the ConfigMap's content) is always
+// the source of truth being converged on.
+func (c *Controller) SyncHandler(key string) error {
+	if _, _, err := cache.SplitMetaNamespaceKey(key); err != nil {
+		return err
+	}
+
+	if err := c.Applier.Apply(c.Resources); err != nil {
+		return err
+	}
+	return c.Pruner.Prune(c.Resources)
+}
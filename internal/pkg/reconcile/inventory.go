@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kustomize/pkg/inventory"
+)
+
+// itemID is the decoded form of an inventory key of the form
+// "~G<group>_<version>_<kind>|<namespace>|<name>".
+type itemID struct {
+	Group     string
+	Version   string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// gvk returns the GroupVersionKind id identifies.
+func (id itemID) gvk() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: id.Group, Version: id.Version, Kind: id.Kind}
+}
+
+// key returns id in the same "~G<group>_<version>_<kind>|<namespace>|<name>"
+// form it was parsed from, so it can be compared against keys produced by
+// itemKey for a live object.
+func (id itemID) key() string {
+	return itemKey(id.gvk(), id.Namespace, id.Name)
+}
+
+// itemKey encodes gvk/namespace/name into the inventory key form Prune
+// compares against the keys already tracked by the inventory ConfigMap.
+func itemKey(gvk schema.GroupVersionKind, namespace, name string) string {
+	return fmt.Sprintf("~G%s_%s_%s|%s|%s", gvk.Group, gvk.Version, gvk.Kind, namespace, name)
+}
+
+// decodeInventory parses the inventory.InventoryAnnotation on cm back into
+// the GVK/namespace/name tuples it refers to.
+func decodeInventory(cm *unstructured.Unstructured) ([]itemID, error) {
+	raw, ok := cm.GetAnnotations()[inventory.InventoryAnnotation]
+	if !ok {
+		return nil, fmt.Errorf("configmap %s/%s has no %s annotation", cm.GetNamespace(), cm.GetName(), inventory.InventoryAnnotation)
+	}
+
+	var doc struct {
+		Current map[string]interface{} `json:"current"`
+	}
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		return nil, fmt.Errorf("decoding %s: %v", inventory.InventoryAnnotation, err)
+	}
+
+	var ids []itemID
+	for key := range doc.Current {
+		id, err := parseItemKey(key)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseItemKey parses a single "~G<group>_<version>_<kind>|<namespace>|<name>" key.
+func parseItemKey(key string) (itemID, error) {
+	parts := strings.Split(key, "|")
+	if len(parts) != 3 || !strings.HasPrefix(parts[0], "~G") {
+		return itemID{}, fmt.Errorf("malformed inventory key %q", key)
+	}
+
+	gvk := strings.SplitN(strings.TrimPrefix(parts[0], "~G"), "_", 3)
+	if len(gvk) != 3 {
+		return itemID{}, fmt.Errorf("malformed inventory key %q", key)
+	}
+
+	return itemID{
+		Group:     gvk[0],
+		Version:   gvk[1],
+		Kind:      gvk[2],
+		Namespace: parts[1],
+		Name:      parts[2],
+	}, nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kustomize/pkg/inventory"
+)
+
+func TestItemKeyRoundTrip(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	key := itemKey(gvk, "default", "web")
+
+	id, err := parseItemKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, itemID{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "default", Name: "web"}, id)
+	assert.Equal(t, gvk, id.gvk())
+	assert.Equal(t, key, id.key())
+}
+
+func TestItemKeyRoundTripCoreGroup(t *testing.T) {
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	key := itemKey(gvk, "default", "cm1")
+
+	id, err := parseItemKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "", id.Group)
+	assert.Equal(t, gvk, id.gvk())
+}
+
+func TestParseItemKeyMalformed(t *testing.T) {
+	_, err := parseItemKey("not-a-valid-key")
+	assert.Error(t, err)
+
+	_, err = parseItemKey("~Gapps_v1_Deployment|default")
+	assert.Error(t, err)
+}
+
+func TestDecodeInventory(t *testing.T) {
+	cm := &unstructured.Unstructured{}
+	cm.SetAnnotations(map[string]string{
+		inventory.InventoryAnnotation: `{"current":{"~Gapps_v1_Deployment|default|web":null}}`,
+	})
+
+	ids, err := decodeInventory(cm)
+	assert.NoError(t, err)
+	assert.Equal(t, []itemID{{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "default", Name: "web"}}, ids)
+}
+
+func TestDecodeInventoryMissingAnnotation(t *testing.T) {
+	cm := &unstructured.Unstructured{}
+	cm.SetAnnotations(map[string]string{})
+
+	_, err := decodeInventory(cm)
+	assert.Error(t, err)
+}
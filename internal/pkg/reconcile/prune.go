@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"sigs.k8s.io/cli-experimental/internal/pkg/apply"
+)
+
+// InventoryPruner deletes cluster objects the inventory ConfigMap tracks
+// that have since dropped out of the desired resource set passed to Prune.
+type InventoryPruner struct {
+	// DynamicClient is used to read the inventory ConfigMap and delete
+	// dropped objects
+	DynamicClient dynamic.Interface
+
+	// Namespace/Name identify the inventory ConfigMap to read
+	Namespace string
+	Name      string
+
+	// Planner resolves each dropped item's GVR and scope via live discovery,
+	// in place of the naive Kind-pluralizing guess the inventory encoding
+	// alone would otherwise require
+	Planner *apply.Planner
+}
+
+// Prune deletes every object the inventory ConfigMap tracks that is not
+// present in resources.
+func (p *InventoryPruner) Prune(resources []*unstructured.Unstructured) error {
+	cm, err := p.DynamicClient.Resource(configMapGVR).Namespace(p.Namespace).Get(p.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("reading inventory configmap %s/%s: %v", p.Namespace, p.Name, err)
+	}
+
+	tracked, err := decodeInventory(cm)
+	if err != nil {
+		return err
+	}
+
+	desired := map[string]bool{}
+	for _, r := range resources {
+		desired[itemKey(r.GroupVersionKind(), r.GetNamespace(), r.GetName())] = true
+	}
+
+	for _, id := range tracked {
+		if desired[id.key()] {
+			continue
+		}
+		if err := p.deleteOne(id); err != nil {
+			return fmt.Errorf("pruning %s %s/%s: %v", id.gvk(), id.Namespace, id.Name, err)
+		}
+	}
+	return nil
+}
+
+func (p *InventoryPruner) deleteOne(id itemID) error {
+	mapping, err := p.Planner.RESTMapping(id.gvk())
+	if err != nil {
+		return err
+	}
+
+	ri := p.DynamicClient.Resource(mapping.Resource)
+	var target dynamic.ResourceInterface = ri
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		target = ri.Namespace(id.Namespace)
+	}
+
+	err = target.Delete(id.Name, &metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
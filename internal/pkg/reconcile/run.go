@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// RunOptions configures RunWithLeaderElection.
+type RunOptions struct {
+	// Clientset is used to hold the leader-election lock
+	Clientset kubernetes.Interface
+
+	// LockNamespace/LockName identify the Lease used to elect a leader, so
+	// multiple replicas of the controller can run safely
+	LockNamespace string
+	LockName      string
+
+	// Identity uniquely identifies this replica, e.g. its pod name
+	Identity string
+
+	// Workers is the number of workers processing the Controller's workqueue
+	Workers int
+}
+
+// RunWithLeaderElection runs the Controller only while holding the
+// leader-election lock, and stops it gracefully on SIGTERM/SIGINT.
+func RunWithLeaderElection(c *Controller, opts RunOptions) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Namespace: opts.LockNamespace,
+			Name:      opts.LockName,
+		},
+		Client: opts.Clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: opts.Identity,
+		},
+	}
+
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		ReleaseOnCancel: true,
+		LeaseDuration:   15 * time.Second,
+		RenewDeadline:   10 * time.Second,
+		RetryPeriod:     2 * time.Second,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				if err := c.Run(opts.Workers, ctx.Done()); err != nil {
+					cancel()
+				}
+			},
+			OnStoppedLeading: func() {
+				cancel()
+			},
+		},
+	})
+
+	return ctx.Err()
+}
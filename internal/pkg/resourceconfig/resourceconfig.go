@@ -14,13 +14,25 @@ limitations under the License.
 package resourceconfig
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	kyaml "k8s.io/apimachinery/pkg/util/yaml"
 	"sigs.k8s.io/kustomize/pkg/fs"
 	"sigs.k8s.io/kustomize/pkg/ifc/transformer"
 	"sigs.k8s.io/kustomize/pkg/loader"
 	"sigs.k8s.io/kustomize/pkg/resmap"
 	"sigs.k8s.io/kustomize/pkg/target"
+	"sigs.k8s.io/yaml"
 )
 
 // ConfigProvider provides runtime.Objects for a path
@@ -39,9 +51,16 @@ type KustomizeProvider struct {
 	FS fs.FileSystem
 }
 
-// IsSupported checks if the path is supported by KustomizeProvider
+// IsSupported checks if the path is a directory containing a
+// kustomization.yaml. Everything else - raw config files/directories and
+// HTTP(S) URLs - is left for RawConfigFileProvider/RawConfigHTTPProvider.
 func (p *KustomizeProvider) IsSupported(path string) bool {
-	return true
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(path, "kustomization.yaml"))
+	return err == nil
 }
 
 // GetConfig returns the resource configs
@@ -68,28 +87,364 @@ func (p *KustomizeProvider) GetConfig(path string) ([]runtime.Object, error) {
 	return results, nil
 }
 
+// SourcePathAnnotation records, on every Unstructured produced by
+// RawConfigFileProvider, the file it was read from. Status uses it to report
+// which file an offending resource came from.
+const SourcePathAnnotation = "config.kubernetes.io/path"
+
+// SourcePath returns the file SourcePathAnnotation recorded u as having been
+// read from, and whether it was set at all - only RawConfigFileProvider sets it.
+func SourcePath(u *unstructured.Unstructured) (string, bool) {
+	path, ok := u.GetAnnotations()[SourcePathAnnotation]
+	return path, ok
+}
+
+// rawConfigFileExtensions lists the file extensions RawConfigFileProvider
+// will treat as raw K8s configuration.
+var rawConfigFileExtensions = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+	".json": true,
+}
+
+// cliIgnoreFile is the name of the file listing glob patterns of files and
+// directories to skip while walking a directory. Each pattern is matched
+// against the base name of a walked path only.
+const cliIgnoreFile = ".cliignore"
+
 // RawConfigFileProvider provides configs from raw K8s configuration files
-type RawConfigFileProvider struct{}
+type RawConfigFileProvider struct {
+	// Recursive controls whether directories are walked recursively. The
+	// zero value (false) only reads the top level of a directory;
+	// NewRawConfigFileProvider in wirek8s sets it from the --recursive flag,
+	// which defaults to true.
+	Recursive bool
+}
 
-// IsSupported checks if a path is a raw K8s configuration file
+// IsSupported checks if a path is a raw K8s configuration file (.yaml, .yml
+// or .json) or a directory that does not contain a kustomization.yaml
 func (p *RawConfigFileProvider) IsSupported(path string) bool {
-	return false
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	if info.IsDir() {
+		// KustomizeProvider takes precedence over directories it understands.
+		_, err := os.Stat(filepath.Join(path, "kustomization.yaml"))
+		return os.IsNotExist(err)
+	}
+
+	return rawConfigFileExtensions[strings.ToLower(filepath.Ext(path))]
 }
 
 // GetConfig returns the resource configs
 func (p *RawConfigFileProvider) GetConfig(path string) ([]runtime.Object, error) {
-	return nil, nil
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return readRawConfigFile(path)
+	}
+
+	ignore, err := loadCliIgnore(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []runtime.Object
+	walkErr := filepath.Walk(path, func(walked string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if walked != path && (!p.Recursive || ignore.matches(walked)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ignore.matches(walked) || !rawConfigFileExtensions[strings.ToLower(filepath.Ext(walked))] {
+			return nil
+		}
+		objs, err := readRawConfigFile(walked)
+		if err != nil {
+			return err
+		}
+		results = append(results, objs...)
+		return nil
+	})
+
+	return results, walkErr
+}
+
+// cliIgnore holds glob patterns loaded from a .cliignore file.
+type cliIgnore struct {
+	patterns []string
+}
+
+func loadCliIgnore(dir string) (*cliIgnore, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, cliIgnoreFile))
+	if os.IsNotExist(err) {
+		return &cliIgnore{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ci := &cliIgnore{}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ci.patterns = append(ci.patterns, line)
+	}
+	return ci, nil
+}
+
+func (ci *cliIgnore) matches(path string) bool {
+	if ci == nil {
+		return false
+	}
+	base := filepath.Base(path)
+	for _, pattern := range ci.patterns {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
 }
 
+// readRawConfigFile streams a single file through a YAML/JSON decoder,
+// tolerating both formats and multiple documents, and annotates each
+// resulting object with the source path it came from.
+func readRawConfigFile(path string) ([]runtime.Object, error) {
+	if filepath.Base(path) == "kustomization.yaml" {
+		return nil, fmt.Errorf(
+			"cannot run on kustomization.yaml - use the directory (%v) instead", filepath.Dir(path))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var results []runtime.Object
+	decoder := kyaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading %s: %v", path, err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		u := &unstructured.Unstructured{Object: obj}
+		annotations := u.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[SourcePathAnnotation] = path
+		u.SetAnnotations(annotations)
+		results = append(results, u)
+	}
+
+	return results, nil
+}
+
+// httpSupportedPrefixes lists the URL schemes RawConfigHTTPProvider will fetch.
+var httpSupportedPrefixes = []string{"http://", "https://", "git+https://"}
+
 // RawConfigHTTPProvider provides configs from HTTP urls
-type RawConfigHTTPProvider struct{}
+type RawConfigHTTPProvider struct {
+	// Client is used to perform the GET request. Defaults to a new
+	// http.Client with default settings.
+	Client *http.Client
+
+	// CacheDir is where downloaded documents are cached, one file per URL
+	// together with its ETag / Last-Modified. Later fetches of the URL send
+	// these as If-None-Match / If-Modified-Since, and a 304 response is
+	// served from the cached copy instead of being downloaded again.
+	CacheDir string
+
+	// Timeout bounds how long a single fetch (including redirects) may take.
+	Timeout time.Duration
+
+	// MaxRedirects caps how many redirects will be followed before giving up.
+	MaxRedirects int
+}
 
 // IsSupported returns if the path points to a HTTP url target
 func (p *RawConfigHTTPProvider) IsSupported(path string) bool {
+	for _, prefix := range httpSupportedPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
 	return false
 }
 
 // GetConfig returns the resource configs
 func (p *RawConfigHTTPProvider) GetConfig(path string) ([]runtime.Object, error) {
-	return nil, nil
+	url := strings.TrimPrefix(path, "git+")
+
+	entry := p.cacheEntry(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.applyAuth(req)
+	if cached, ok := entry.load(); ok {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	client := p.client()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching raw config from %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		cached, ok := entry.load()
+		if !ok {
+			return nil, fmt.Errorf("got 304 Not Modified for %s but no cached copy exists", url)
+		}
+		return parseMultiDocYAML(cached.body)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("fetching raw config from %s: unexpected status %s", url, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	entry.save(body, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"))
+
+	return parseMultiDocYAML(body)
+}
+
+// client returns the configured http.Client, applying Timeout and
+// MaxRedirects if set.
+func (p *RawConfigHTTPProvider) client() *http.Client {
+	c := p.Client
+	if c == nil {
+		c = &http.Client{}
+	}
+	if p.Timeout > 0 {
+		c.Timeout = p.Timeout
+	}
+	if p.MaxRedirects > 0 {
+		max := p.MaxRedirects
+		c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) >= max {
+				return fmt.Errorf("stopped after %d redirects", max)
+			}
+			return nil
+		}
+	}
+	return c
+}
+
+// applyAuth sets bearer-token or basic-auth headers sourced from the
+// environment, so private Git raw URLs (e.g. GitHub/GitLab raw content) work.
+func (p *RawConfigHTTPProvider) applyAuth(req *http.Request) {
+	if token := os.Getenv("CLI_RAW_CONFIG_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return
+	}
+	user := os.Getenv("CLI_RAW_CONFIG_USERNAME")
+	pass := os.Getenv("CLI_RAW_CONFIG_PASSWORD")
+	if user != "" || pass != "" {
+		req.SetBasicAuth(user, pass)
+	}
+}
+
+// cacheFile describes the on-disk representation of a cached fetch.
+type cacheFile struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+// cacheEntry points at the cache file for a single URL.
+type cacheEntry struct {
+	dir  string
+	path string
+}
+
+func (p *RawConfigHTTPProvider) cacheEntry(url string) *cacheEntry {
+	if p.CacheDir == "" {
+		return &cacheEntry{}
+	}
+	sum := sha256.Sum256([]byte(url))
+	return &cacheEntry{
+		dir:  p.CacheDir,
+		path: filepath.Join(p.CacheDir, fmt.Sprintf("%x", sum)),
+	}
+}
+
+func (c *cacheEntry) load() (cacheFile, bool) {
+	if c.path == "" {
+		return cacheFile{}, false
+	}
+	body, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return cacheFile{}, false
+	}
+	meta, err := ioutil.ReadFile(c.path + ".meta")
+	if err != nil {
+		return cacheFile{body: body}, true
+	}
+	parts := strings.SplitN(string(meta), "\n", 2)
+	cf := cacheFile{body: body}
+	if len(parts) > 0 {
+		cf.etag = parts[0]
+	}
+	if len(parts) > 1 {
+		cf.lastModified = parts[1]
+	}
+	return cf, true
+}
+
+func (c *cacheEntry) save(body []byte, etag, lastModified string) {
+	if c.path == "" {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return
+	}
+	_ = ioutil.WriteFile(c.path, body, 0644)
+	_ = ioutil.WriteFile(c.path+".meta", []byte(etag+"\n"+lastModified), 0644)
+}
+
+// parseMultiDocYAML splits a multi-document YAML/JSON body on "---" and
+// unmarshals each document into an *unstructured.Unstructured.
+func parseMultiDocYAML(body []byte) ([]runtime.Object, error) {
+	var results []runtime.Object
+	for _, doc := range strings.Split(string(body), "---") {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, err
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		results = append(results, &unstructured.Unstructured{Object: obj})
+	}
+	return results, nil
 }
@@ -0,0 +1,179 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourceconfig
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+const configMapYAML = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm1
+`
+
+func TestRawConfigFileProviderIsSupported(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestRawConfigFileProvider")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	yamlFile := filepath.Join(dir, "cm.yaml")
+	writeFile(t, yamlFile, configMapYAML)
+	txtFile := filepath.Join(dir, "readme.txt")
+	writeFile(t, txtFile, "not a resource config")
+
+	p := &RawConfigFileProvider{Recursive: true}
+	assert.True(t, p.IsSupported(yamlFile))
+	assert.False(t, p.IsSupported(txtFile))
+	assert.True(t, p.IsSupported(dir))
+
+	writeFile(t, filepath.Join(dir, "kustomization.yaml"), "")
+	assert.False(t, p.IsSupported(dir), "a directory with a kustomization.yaml is left for KustomizeProvider")
+}
+
+func TestRawConfigFileProviderGetConfigSetsSourcePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestRawConfigFileProvider")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	yamlFile := filepath.Join(dir, "cm.yaml")
+	writeFile(t, yamlFile, configMapYAML)
+
+	p := &RawConfigFileProvider{Recursive: true}
+	objs, err := p.GetConfig(yamlFile)
+	assert.NoError(t, err)
+	assert.Len(t, objs, 1)
+
+	u, ok := objs[0].(interface{ GetAnnotations() map[string]string })
+	assert.True(t, ok)
+	assert.Equal(t, yamlFile, u.GetAnnotations()[SourcePathAnnotation])
+}
+
+func TestRawConfigFileProviderWalksDirectoryAndRespectsCliIgnore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestRawConfigFileProvider")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile(t, filepath.Join(dir, "a.yaml"), configMapYAML)
+	writeFile(t, filepath.Join(dir, "b.yaml"), configMapYAML)
+	writeFile(t, filepath.Join(dir, "readme.md"), "not a resource config")
+	writeFile(t, filepath.Join(dir, ".cliignore"), "b.yaml\n")
+
+	p := &RawConfigFileProvider{Recursive: true}
+	objs, err := p.GetConfig(dir)
+	assert.NoError(t, err)
+	assert.Len(t, objs, 1)
+}
+
+func TestRawConfigFileProviderNonRecursiveSkipsSubdirectories(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestRawConfigFileProvider")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile(t, filepath.Join(dir, "a.yaml"), configMapYAML)
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	writeFile(t, filepath.Join(dir, "sub", "b.yaml"), configMapYAML)
+
+	p := &RawConfigFileProvider{Recursive: false}
+	objs, err := p.GetConfig(dir)
+	assert.NoError(t, err)
+	assert.Len(t, objs, 1)
+}
+
+func TestRawConfigHTTPProviderIsSupported(t *testing.T) {
+	p := &RawConfigHTTPProvider{}
+	assert.True(t, p.IsSupported("https://example.com/manifest.yaml"))
+	assert.True(t, p.IsSupported("http://example.com/manifest.yaml"))
+	assert.False(t, p.IsSupported("/tmp/manifest.yaml"))
+}
+
+func TestRawConfigHTTPProviderGetConfig(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(configMapYAML))
+	}))
+	defer srv.Close()
+
+	p := &RawConfigHTTPProvider{}
+	objs, err := p.GetConfig(srv.URL)
+	assert.NoError(t, err)
+	assert.Len(t, objs, 1)
+}
+
+func TestRawConfigHTTPProviderSendsAuthHeader(t *testing.T) {
+	os.Setenv("CLI_RAW_CONFIG_TOKEN", "s3cr3t")
+	defer os.Unsetenv("CLI_RAW_CONFIG_TOKEN")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer s3cr3t", r.Header.Get("Authorization"))
+		w.Write([]byte(configMapYAML))
+	}))
+	defer srv.Close()
+
+	p := &RawConfigHTTPProvider{}
+	_, err := p.GetConfig(srv.URL)
+	assert.NoError(t, err)
+}
+
+func TestRawConfigHTTPProviderCachesOn304(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestRawConfigHTTPProviderCache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == "abc" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", "abc")
+		w.Write([]byte(configMapYAML))
+	}))
+	defer srv.Close()
+
+	p := &RawConfigHTTPProvider{CacheDir: dir}
+
+	objs, err := p.GetConfig(srv.URL)
+	assert.NoError(t, err)
+	assert.Len(t, objs, 1)
+
+	objs, err = p.GetConfig(srv.URL)
+	assert.NoError(t, err)
+	assert.Len(t, objs, 1)
+	assert.Equal(t, 2, requests)
+}
+
+func TestRawConfigHTTPProviderMaxRedirects(t *testing.T) {
+	var redirectURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, redirectURL, http.StatusFound)
+	}))
+	defer srv.Close()
+	redirectURL = srv.URL
+
+	p := &RawConfigHTTPProvider{MaxRedirects: 2}
+	_, err := p.GetConfig(srv.URL)
+	assert.Error(t, err)
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cli-experimental/internal/pkg/apply"
+	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
+	"sigs.k8s.io/cli-experimental/internal/pkg/resourceconfig"
+)
+
+// defaultResync is how often the informers backing watch mode relist, as a
+// backstop against missed watch events.
+const defaultResync = 10 * time.Minute
+
+// Status computes and reports on the status of a set of resources
+type Status struct {
+	// Resources are the resources to report status for
+	Resources clik8s.ResourceConfigs
+
+	// DynamicClient is used to build informers when Watch is set
+	DynamicClient dynamic.Interface
+
+	// Planner resolves each resource's GVR via live discovery when Watch is
+	// set, in place of a naive Kind-pluralizing guess. Required when Watch
+	// is set.
+	Planner *apply.Planner
+
+	// Out is where status transitions are streamed to in watch mode
+	Out io.Writer
+
+	// Watch, when true, makes Do block until every resource is Ready or ctx
+	// is done, streaming ResourceState transitions to Out as JSON lines
+	Watch bool
+}
+
+// Result contains the result of computing Status
+type Result struct {
+	// Resources is the number of resources Status was computed for
+	Resources int
+
+	// ResourceStates is the readiness of each resource. It is populated by
+	// Wait, and by Do when Watch is set.
+	ResourceStates []ResourceState
+}
+
+// ResourceState is the computed readiness of a single resource
+type ResourceState struct {
+	GroupVersionKind schema.GroupVersionKind `json:"groupVersionKind"`
+	Namespace        string                  `json:"namespace"`
+	Name             string                  `json:"name"`
+	Ready            bool                    `json:"ready"`
+	Message          string                  `json:"message,omitempty"`
+}
+
+// Do computes the Status of the Resources, blocking until all of them
+// are Ready if Watch is set.
+func (s *Status) Do(ctx context.Context) (Result, error) {
+	if !s.Watch {
+		return Result{Resources: len(s.Resources)}, nil
+	}
+	return s.watch(ctx, allReady)
+}
+
+// Wait blocks until every resource in the set has reported a state and
+// predicate returns true for those states, or ctx is done, whichever comes
+// first.
+func (s *Status) Wait(ctx context.Context, predicate func([]ResourceState) bool) (Result, error) {
+	return s.watch(ctx, predicate)
+}
+
+// watch builds a shared informer for each GVK present in Resources, computes
+// a readiness condition for each resource in Resources on every update,
+// streams transitions to Out and returns once every resource in Resources has
+// reported a state and predicate is satisfied, or ctx is done. Informer events for objects that merely share a GVK with
+// something in Resources, but aren't in it, are ignored.
+func (s *Status) watch(ctx context.Context, predicate func([]ResourceState) bool) (Result, error) {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(s.DynamicClient, defaultResync)
+
+	wanted := wantedKeys(s.Resources)
+	total := 0
+	for _, names := range wanted {
+		total += len(names)
+	}
+	gvrs, err := gvrsFor(s.Resources, s.Planner)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var mu sync.Mutex
+	states := map[string]ResourceState{}
+	done := make(chan struct{})
+	var closeOnce sync.Once
+
+	emit := func(u *unstructured.Unstructured) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		state := computeState(u)
+		key := fmt.Sprintf("%s|%s|%s", state.GroupVersionKind, state.Namespace, state.Name)
+		if old, ok := states[key]; ok && old == state {
+			return
+		}
+		states[key] = state
+		s.writeState(state)
+
+		if len(states) == total && predicate(flatten(states)) {
+			closeOnce.Do(func() { close(done) })
+		}
+	}
+
+	for gvk, gvr := range gvrs {
+		names := wanted[gvk]
+		handle := func(obj interface{}) { emitWanted(obj, names, emit) }
+		informer := factory.ForResource(gvr).Informer()
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    handle,
+			UpdateFunc: func(_, obj interface{}) { handle(obj) },
+		})
+	}
+
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	select {
+	case <-ctx.Done():
+	case <-done:
+	}
+
+	return Result{
+		Resources:      len(s.Resources),
+		ResourceStates: flatten(states),
+	}, ctx.Err()
+}
+
+// wantedKeys groups the "namespace/name" of each resource in resources by
+// GVK, so watch can tell informer events for Resources apart from events for
+// unrelated objects of the same GVK.
+func wantedKeys(resources clik8s.ResourceConfigs) map[schema.GroupVersionKind]map[string]bool {
+	keys := map[schema.GroupVersionKind]map[string]bool{}
+	for _, r := range resources {
+		accessor, err := meta.Accessor(r)
+		if err != nil {
+			continue
+		}
+		gvk := r.GetObjectKind().GroupVersionKind()
+		if keys[gvk] == nil {
+			keys[gvk] = map[string]bool{}
+		}
+		keys[gvk][accessor.GetNamespace()+"/"+accessor.GetName()] = true
+	}
+	return keys
+}
+
+// emitWanted calls emit for obj if it is an Unstructured whose
+// "namespace/name" is in names.
+func emitWanted(obj interface{}, names map[string]bool, emit func(*unstructured.Unstructured)) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok || !names[u.GetNamespace()+"/"+u.GetName()] {
+		return
+	}
+	emit(u)
+}
+
+// writeState streams a single ResourceState transition to Out as a JSON line.
+func (s *Status) writeState(state ResourceState) {
+	if s.Out == nil {
+		return
+	}
+	b, err := json.Marshal(state)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(s.Out, "%s\n", b)
+}
+
+func flatten(states map[string]ResourceState) []ResourceState {
+	result := make([]ResourceState, 0, len(states))
+	for _, s := range states {
+		result = append(result, s)
+	}
+	return result
+}
+
+func allReady(states []ResourceState) bool {
+	for _, s := range states {
+		if !s.Ready {
+			return false
+		}
+	}
+	return true
+}
+
+// gvrsFor resolves the GVR to watch for each distinct GVK present in
+// resources via planner, since the watched GVR must match the API server's
+// actual REST path - a pluralization guess gets Kinds like Ingress
+// ("ingresses"), Endpoints ("endpoints") or NetworkPolicy ("networkpolicies")
+// wrong.
+func gvrsFor(resources clik8s.ResourceConfigs, planner *apply.Planner) (map[schema.GroupVersionKind]schema.GroupVersionResource, error) {
+	gvrs := map[schema.GroupVersionKind]schema.GroupVersionResource{}
+	for _, r := range resources {
+		gvk := r.GetObjectKind().GroupVersionKind()
+		if _, ok := gvrs[gvk]; ok {
+			continue
+		}
+		mapping, err := planner.RESTMapping(gvk)
+		if err != nil {
+			return nil, fmt.Errorf("resolving REST mapping for %s: %v", gvk, err)
+		}
+		gvrs[gvk] = mapping.Resource
+	}
+	return gvrs, nil
+}
+
+// computeState derives a readiness condition for a single resource:
+// Deployment Available, StatefulSet ReadyReplicas==Replicas, Job Complete,
+// PVC Bound, or else the generic status.conditions[Ready].
+func computeState(u *unstructured.Unstructured) ResourceState {
+	state := ResourceState{
+		GroupVersionKind: u.GroupVersionKind(),
+		Namespace:        u.GetNamespace(),
+		Name:             u.GetName(),
+	}
+
+	switch u.GetKind() {
+	case "Deployment":
+		state.Ready, state.Message = conditionStatus(u, "Available")
+	case "StatefulSet":
+		state.Ready, state.Message = replicasReady(u)
+	case "Job":
+		state.Ready, state.Message = conditionStatus(u, "Complete")
+	case "PersistentVolumeClaim":
+		phase, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+		state.Ready = phase == "Bound"
+		state.Message = phase
+	default:
+		state.Ready, state.Message = conditionStatus(u, "Ready")
+	}
+
+	if !state.Ready {
+		if path, ok := resourceconfig.SourcePath(u); ok {
+			state.Message = fmt.Sprintf("%s (defined in %s)", state.Message, path)
+		}
+	}
+
+	return state
+}
+
+func conditionStatus(u *unstructured.Unstructured, condType string) (bool, string) {
+	conditions, found, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+	if err != nil || !found {
+		return false, "no status.conditions reported yet"
+	}
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != condType {
+			continue
+		}
+		status, _ := cond["status"].(string)
+		message, _ := cond["message"].(string)
+		return status == "True", message
+	}
+	return false, fmt.Sprintf("condition %s not reported yet", condType)
+}
+
+func replicasReady(u *unstructured.Unstructured) (bool, string) {
+	replicas, _, _ := unstructured.NestedInt64(u.Object, "spec", "replicas")
+	ready, _, _ := unstructured.NestedInt64(u.Object, "status", "readyReplicas")
+	return ready == replicas, fmt.Sprintf("%d/%d replicas ready", ready, replicas)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"sigs.k8s.io/cli-experimental/internal/pkg/apply"
+	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
+	"sigs.k8s.io/cli-experimental/internal/pkg/discoveryfake"
+	"sigs.k8s.io/cli-experimental/internal/pkg/resourceconfig"
+)
+
+func newIngress(namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("networking.k8s.io/v1beta1")
+	u.SetKind("Ingress")
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+// TestGvrsForResolvesIrregularPlurals guards against the naive
+// strings.ToLower(kind)+"s" guess this used to make, which gets Kinds like
+// Ingress wrong ("ingresss" instead of "ingresses").
+func TestGvrsForResolvesIrregularPlurals(t *testing.T) {
+	dc := discoveryfake.New()
+	dc.Add(schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingresses"}, true)
+	planner := apply.NewPlanner(dc)
+
+	ingress := newIngress("default", "web")
+	gvrs, err := gvrsFor(clik8s.ResourceConfigs{ingress}, planner)
+	assert.NoError(t, err)
+
+	gvr, ok := gvrs[ingress.GroupVersionKind()]
+	assert.True(t, ok)
+	assert.Equal(t, "ingresses", gvr.Resource)
+}
+
+func TestGvrsForPropagatesUnmappedGVKs(t *testing.T) {
+	planner := apply.NewPlanner(discoveryfake.New())
+	_, err := gvrsFor(clik8s.ResourceConfigs{newIngress("default", "web")}, planner)
+	assert.Error(t, err)
+}
+
+func TestComputeStateIncludesSourcePathWhenNotReady(t *testing.T) {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("apps/v1")
+	u.SetKind("Deployment")
+	u.SetNamespace("default")
+	u.SetName("web")
+	u.SetAnnotations(map[string]string{resourceconfig.SourcePathAnnotation: "manifests/web.yaml"})
+
+	state := computeState(u)
+	assert.False(t, state.Ready)
+	assert.Contains(t, state.Message, "manifests/web.yaml")
+}
+
+func TestComputeStateOmitsSourcePathWhenReady(t *testing.T) {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("PersistentVolumeClaim")
+	u.SetNamespace("default")
+	u.SetName("data")
+	u.SetAnnotations(map[string]string{resourceconfig.SourcePathAnnotation: "manifests/pvc.yaml"})
+	assert.NoError(t, unstructured.SetNestedField(u.Object, "Bound", "status", "phase"))
+
+	state := computeState(u)
+	assert.True(t, state.Ready)
+	assert.NotContains(t, state.Message, "manifests/pvc.yaml")
+}
+
+func TestWantedKeysAndEmitWanted(t *testing.T) {
+	tracked := newIngress("default", "web")
+	untracked := newIngress("default", "other")
+
+	wanted := wantedKeys(clik8s.ResourceConfigs{tracked})
+	names := wanted[tracked.GroupVersionKind()]
+
+	var emitted []*unstructured.Unstructured
+	emit := func(u *unstructured.Unstructured) { emitted = append(emitted, u) }
+
+	emitWanted(tracked, names, emit)
+	emitWanted(untracked, names, emit)
+
+	assert.Len(t, emitted, 1)
+	assert.Equal(t, "web", emitted[0].GetName())
+}
@@ -15,14 +15,16 @@ package wirek8s
 
 import (
 	"fmt"
-	"io/ioutil"
+	"os"
 	"path/filepath"
-	"strings"
+	"time"
 
 	"github.com/google/wire"
 	"github.com/spf13/cobra"
 
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -34,7 +36,6 @@ import (
 	"sigs.k8s.io/kustomize/pkg/fs"
 	"sigs.k8s.io/kustomize/pkg/ifc/transformer"
 	"sigs.k8s.io/kustomize/pkg/resmap"
-	"sigs.k8s.io/yaml"
 
 	// for connecting to various types of hosted clusters
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -43,13 +44,15 @@ import (
 // ConfigProviderSet defines dependencies for initializing ConfigProvider
 var ConfigProviderSet = wire.NewSet(
 	NewKustomizeFactory, NewResMapFactory, NewTransformerFactory,
-	NewFileSystem, NewConfigProvider)
+	NewFileSystem, NewConfigProvider, NewRawConfigHTTPProvider, NewRawConfigFileProvider)
 
 // ProviderSet defines dependencies for initializing Kubernetes objects
 var ProviderSet = wire.NewSet(NewKubernetesClientSet, NewKubeConfigPathFlag, NewRestConfig,
-	NewMasterFlag, NewResourceConfig, ConfigProviderSet)
+	NewMasterFlag, NewResourceConfig, NewDynamicClient, NewDiscoveryClient, ConfigProviderSet)
 var kubeConfigPathFlag string
 var master string
+var rawConfigTimeout time.Duration
+var recursive bool
 
 // Flags registers flags for talkig to a Kubernetes cluster
 func Flags(command *cobra.Command) {
@@ -64,6 +67,10 @@ func Flags(command *cobra.Command) {
 		"kubeconfig", path, "absolute path to the kubeconfig file")
 	command.Flags().StringVar(&master,
 		"master", "", "address of master")
+	command.Flags().DurationVar(&rawConfigTimeout,
+		"raw-config-timeout", 30*time.Second, "timeout for fetching raw resource config over HTTP(S)")
+	command.Flags().BoolVar(&recursive,
+		"recursive", true, "read raw config directories recursively")
 }
 
 // NewKubeConfigPathFlag provides the path to the kubeconfig file
@@ -115,47 +122,57 @@ func NewKubernetesClientSet(c *rest.Config) (*kubernetes.Clientset, error) {
 	return kubernetes.NewForConfig(c)
 }
 
-// NewResourceConfig provides ResourceConfigs read from the ResourceConfigPath and FileSystem.
-func NewResourceConfig(rcp clik8s.ResourceConfigPath, cp resourceconfig.ConfigProvider) (clik8s.ResourceConfigs, error) {
-	p := string(rcp)
-	var values clik8s.ResourceConfigs
-
-	if cp.IsSupported(p) {
-		return cp.GetConfig(p)
-	}
+// NewDynamicClient provides a dynamic.Interface, used to build the shared
+// informers backing `cli apply status --watch`
+func NewDynamicClient(c *rest.Config) (dynamic.Interface, error) {
+	return dynamic.NewForConfig(c)
+}
 
-	r, err := doFile(p)
+// NewDiscoveryClient provides a memory-cached discovery client, used to
+// build the GVK->REST mapping that drives CRD/Namespace ordering and
+// namespaced-vs-cluster-scoped pruning
+func NewDiscoveryClient(c *rest.Config) (discovery.CachedDiscoveryInterface, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(c)
 	if err != nil {
 		return nil, err
 	}
-	values = append(values, r...)
+	return memory.NewMemCacheClient(dc), nil
+}
 
-	return values, nil
+// NewRawConfigHTTPProvider provides a ConfigProvider for fetching manifests over HTTP(S)
+func NewRawConfigHTTPProvider() *resourceconfig.RawConfigHTTPProvider {
+	cacheDir := filepath.Join(os.TempDir(), "cli-experimental", "raw-config-cache")
+	return &resourceconfig.RawConfigHTTPProvider{
+		CacheDir:     cacheDir,
+		Timeout:      rawConfigTimeout,
+		MaxRedirects: 10,
+	}
 }
 
-func doFile(p string) (clik8s.ResourceConfigs, error) {
-	var values clik8s.ResourceConfigs
+// NewRawConfigFileProvider provides a ConfigProvider for reading raw K8s
+// configuration files and directories from disk
+func NewRawConfigFileProvider() *resourceconfig.RawConfigFileProvider {
+	return &resourceconfig.RawConfigFileProvider{
+		Recursive: recursive,
+	}
+}
 
-	// Don't allow running on kustomization.yaml, prevents weird things like globbing
-	if filepath.Base(p) == "kustomization.yaml" {
-		return nil, fmt.Errorf(
-			"cannot run on kustomization.yaml - use the directory (%v) instead", filepath.Dir(p))
+// NewResourceConfig provides ResourceConfigs read from the ResourceConfigPath and FileSystem.
+func NewResourceConfig(rcp clik8s.ResourceConfigPath, cp resourceconfig.ConfigProvider,
+	hp *resourceconfig.RawConfigHTTPProvider, fp *resourceconfig.RawConfigFileProvider) (clik8s.ResourceConfigs, error) {
+	p := string(rcp)
+
+	if cp.IsSupported(p) {
+		return cp.GetConfig(p)
 	}
 
-	// Resource file
-	b, err := ioutil.ReadFile(p)
-	if err != nil {
-		return nil, err
+	if hp.IsSupported(p) {
+		return hp.GetConfig(p)
 	}
-	objs := strings.Split(string(b), "---")
-	for _, o := range objs {
-		body := map[string]interface{}{}
-
-		if err := yaml.Unmarshal([]byte(o), &body); err != nil {
-			return nil, err
-		}
-		values = append(values, &unstructured.Unstructured{Object: body})
+
+	if fp.IsSupported(p) {
+		return fp.GetConfig(p)
 	}
 
-	return values, nil
+	return nil, fmt.Errorf("%s is not supported by any resource config provider", p)
 }